@@ -0,0 +1,68 @@
+// Package prometheus provides a ready-made leakybucket/redis.Observer
+// that exports Prometheus metrics, so callers don't have to hand-roll
+// counters and histograms for Add outcomes.
+package prometheus
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	leakyredis "github.com/DropLive/leakybucket/redis"
+)
+
+// Observer implements leakyredis.Observer, exporting:
+//
+//   - leakybucket_requests_total{result="allow|deny|error"}
+//   - leakybucket_denied_total
+//   - leakybucket_redis_duration_seconds
+//
+// Bucket names are deliberately not used as a label: they're typically
+// per-user or per-key and would give Prometheus an unbounded number of
+// time series to track.
+type Observer struct {
+	requestsTotal *prometheus.CounterVec
+	deniedTotal   prometheus.Counter
+	redisDuration prometheus.Histogram
+}
+
+var _ leakyredis.Observer = (*Observer)(nil)
+
+// NewObserver builds an Observer and registers its metrics with reg. Pass
+// prometheus.DefaultRegisterer to use the global registry.
+func NewObserver(reg prometheus.Registerer) *Observer {
+	o := &Observer{
+		requestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "leakybucket_requests_total",
+			Help: "Total Add calls, labeled by result.",
+		}, []string{"result"}),
+		deniedTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "leakybucket_denied_total",
+			Help: "Total Add calls denied for exceeding capacity.",
+		}),
+		redisDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name: "leakybucket_redis_duration_seconds",
+			Help: "Latency of the Redis round-trip behind a successful Add.",
+		}),
+	}
+
+	reg.MustRegister(o.requestsTotal, o.deniedTotal, o.redisDuration)
+	return o
+}
+
+// OnAllow implements leakyredis.Observer.
+func (o *Observer) OnAllow(bucket string, amount, remaining uint, latency time.Duration) {
+	o.requestsTotal.WithLabelValues("allow").Inc()
+	o.redisDuration.Observe(latency.Seconds())
+}
+
+// OnDeny implements leakyredis.Observer.
+func (o *Observer) OnDeny(bucket string, amount uint, resetIn time.Duration) {
+	o.requestsTotal.WithLabelValues("deny").Inc()
+	o.deniedTotal.Inc()
+}
+
+// OnError implements leakyredis.Observer.
+func (o *Observer) OnError(bucket string, err error) {
+	o.requestsTotal.WithLabelValues("error").Inc()
+}
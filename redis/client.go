@@ -0,0 +1,46 @@
+package redis
+
+import (
+	"context"
+
+	leakybucket "github.com/DropLive/leakybucket"
+)
+
+// ContextBucket is a leakybucket.Bucket that also exposes the
+// context-aware AddContext. Storage.Create/CreateContext and
+// SlidingWindowStorage.Create/CreateContext return this (rather than the
+// bare leakybucket.Bucket) so callers don't need an unexported-type
+// assertion to reach AddContext.
+type ContextBucket interface {
+	leakybucket.Bucket
+	AddContext(ctx context.Context, amount uint) (leakybucket.BucketState, error)
+}
+
+// RedisClient is the minimal surface Bucket and Storage need from a Redis
+// driver. NewBucket and NewBucketWithOptions back Storage with redigo by
+// default; NewStorageFromClient lets callers supply anything else —
+// go-redis (see the sibling goredis package), a cluster client, or a
+// client wrapped with tracing — by implementing this interface.
+type RedisClient interface {
+	// Do runs a single command, returning the reply in the same shape
+	// redigo's Conn.Do does (so the redigo decode helpers keep working
+	// regardless of which driver actually ran it).
+	Do(ctx context.Context, cmd string, args ...interface{}) (interface{}, error)
+
+	// EvalSha runs script via EVALSHA, falling back to EVAL on a NOSCRIPT
+	// reply. Implementations are free to cache the compiled script
+	// however their underlying driver prefers.
+	EvalSha(ctx context.Context, script *Script, keys []string, args ...interface{}) (interface{}, error)
+
+	// Close releases any resources the client holds (connections, pools).
+	Close() error
+}
+
+// Script is a Lua script run via EVALSHA with a fallback to EVAL, in a
+// form that doesn't assume any particular Redis driver.
+type Script struct {
+	// KeyCount is the number of leading arguments to EvalSha that are
+	// Redis keys rather than plain arguments.
+	KeyCount int
+	Source   string
+}
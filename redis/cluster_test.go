@@ -0,0 +1,44 @@
+package redis
+
+import "testing"
+
+// TestCRC16 checks crc16 against the standard CRC16-CCITT (XMODEM) check
+// value for "123456789" (0x31C3), the reference vector Redis Cluster's
+// own crc16.c is checked against.
+func TestCRC16(t *testing.T) {
+	if got := crc16("123456789"); got != 0x31C3 {
+		t.Fatalf("crc16(%q) = %#04x, want 0x31c3", "123456789", got)
+	}
+}
+
+// TestClusterRouterClientFor checks that a bucket name is routed to the
+// node owning crc16(name)%clusterSlots, and that a slot no node claims
+// surfaces an error rather than a nil client.
+func TestClusterRouterClientFor(t *testing.T) {
+	name := "some-bucket"
+	slot := int(crc16(name)) % clusterSlots
+
+	owner := &redigoClient{}
+	router := &clusterRouter{nodes: []clusterNode{
+		{start: 0, end: slot, client: owner},
+		{start: slot + 1, end: clusterSlots - 1, client: &redigoClient{}},
+	}}
+
+	got, err := router.clientFor(name)
+	if err != nil {
+		t.Fatalf("clientFor(%q): %v", name, err)
+	}
+	if got != RedisClient(owner) {
+		t.Fatalf("clientFor(%q) returned the wrong node for slot %d", name, slot)
+	}
+
+	unowned := &clusterRouter{}
+	if slot > 0 {
+		unowned.nodes = []clusterNode{{start: 0, end: slot - 1, client: owner}}
+	} else {
+		unowned.nodes = []clusterNode{{start: 1, end: clusterSlots - 1, client: owner}}
+	}
+	if _, err := unowned.clientFor(name); err == nil {
+		t.Fatalf("clientFor(%q) on an unowned slot: want error, got nil", name)
+	}
+}
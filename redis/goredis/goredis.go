@@ -0,0 +1,77 @@
+// Package goredis adapts a go-redis v9 client to leakybucket/redis's
+// RedisClient interface, so a Storage can be backed by go-redis instead
+// of the default redigo driver.
+package goredis
+
+import (
+	"context"
+	"sync"
+
+	redigo "github.com/garyburd/redigo/redis"
+	goredis "github.com/redis/go-redis/v9"
+
+	leakyredis "github.com/DropLive/leakybucket/redis"
+)
+
+// Client adapts a go-redis UniversalClient — a *goredis.Client, a
+// *goredis.ClusterClient, a *goredis.Ring, or anything OpenTelemetry-
+// wrapped around one of those — to leakyredis.RedisClient.
+type Client struct {
+	rdb goredis.UniversalClient
+
+	mu      sync.Mutex
+	scripts map[*leakyredis.Script]*goredis.Script
+}
+
+// New wraps an already-configured go-redis client. Build it with
+// leakyredis.NewStorageFromClient(goredis.New(rdb)).
+func New(rdb goredis.UniversalClient) *Client {
+	return &Client{rdb: rdb, scripts: make(map[*leakyredis.Script]*goredis.Script)}
+}
+
+// Do implements leakyredis.RedisClient.
+func (c *Client) Do(ctx context.Context, cmd string, args ...interface{}) (interface{}, error) {
+	cmdArgs := make([]interface{}, 0, len(args)+1)
+	cmdArgs = append(cmdArgs, cmd)
+	cmdArgs = append(cmdArgs, args...)
+	reply, err := c.rdb.Do(ctx, cmdArgs...).Result()
+	if err != nil {
+		if err == goredis.Nil {
+			return nil, redigo.ErrNil
+		}
+		return nil, err
+	}
+	// go-redis decodes a RESP bulk string as a native Go string, while
+	// redigo's Conn.Do returns []byte for the same reply; the redigo
+	// decode helpers (redis.Int64, redis.Uint64, ...) only handle the
+	// latter, so translate to keep this driver a drop-in.
+	if s, ok := reply.(string); ok {
+		return []byte(s), nil
+	}
+	return reply, nil
+}
+
+// script returns the cached go-redis Script for s, compiling and caching
+// it on first use. go-redis's Script.Run already does EVALSHA with an
+// EVAL fallback on NOSCRIPT.
+func (c *Client) script(s *leakyredis.Script) *goredis.Script {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gs, ok := c.scripts[s]; ok {
+		return gs
+	}
+	gs := goredis.NewScript(s.Source)
+	c.scripts[s] = gs
+	return gs
+}
+
+// EvalSha implements leakyredis.RedisClient.
+func (c *Client) EvalSha(ctx context.Context, script *leakyredis.Script, keys []string, args ...interface{}) (interface{}, error) {
+	return c.script(script).Run(ctx, c.rdb, keys, args...).Result()
+}
+
+// Close implements leakyredis.RedisClient.
+func (c *Client) Close() error {
+	return c.rdb.Close()
+}
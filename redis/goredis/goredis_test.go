@@ -0,0 +1,54 @@
+package goredis_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredisv9 "github.com/redis/go-redis/v9"
+
+	leakyredis "github.com/DropLive/leakybucket/redis"
+	"github.com/DropLive/leakybucket/redis/goredis"
+)
+
+// TestStorageCreateAndAddAgainstGoRedis exercises Storage.CreateContext
+// and Bucket.AddContext end to end through a go-redis-backed
+// NewStorageFromClient, against a real (miniredis) server rather than
+// redigo. It guards against go-redis decoding a GET bulk-string reply as
+// a native string, which the redigo-oriented decode helpers
+// (redis.Uint64, redis.Int64, ...) can't handle.
+func TestStorageCreateAndAddAgainstGoRedis(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	rdb := goredisv9.NewClient(&goredisv9.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	storage := leakyredis.NewStorageFromClient(goredis.New(rdb))
+
+	const capacity = 3
+	bucket, err := storage.CreateContext(context.Background(), "go-redis-bucket", capacity, time.Minute)
+	if err != nil {
+		t.Fatalf("CreateContext: %v", err)
+	}
+
+	for i := 0; i < capacity; i++ {
+		if _, err := bucket.AddContext(context.Background(), 1); err != nil {
+			t.Fatalf("AddContext %d: %v", i, err)
+		}
+	}
+
+	if _, err := bucket.AddContext(context.Background(), 1); err == nil {
+		t.Fatal("AddContext beyond capacity: want error, got nil")
+	}
+
+	// Recreating against the same key must read back the counter GET
+	// wrote, not fail decoding it.
+	again, err := storage.CreateContext(context.Background(), "go-redis-bucket", capacity, time.Minute)
+	if err != nil {
+		t.Fatalf("CreateContext (existing key): %v", err)
+	}
+	if remaining := again.Remaining(); remaining != 0 {
+		t.Fatalf("Remaining() = %d, want 0", remaining)
+	}
+}
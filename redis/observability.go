@@ -0,0 +1,86 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Observer receives lifecycle events from Storage and SlidingWindowStorage
+// buckets, for metrics and logging. See metrics/prometheus for a
+// ready-made implementation.
+type Observer interface {
+	// OnAllow is called after an Add that was under capacity.
+	OnAllow(bucket string, amount, remaining uint, latency time.Duration)
+	// OnDeny is called after an Add that was rejected for exceeding
+	// capacity.
+	OnDeny(bucket string, amount uint, resetIn time.Duration)
+	// OnError is called whenever Add or Create returns an error other
+	// than leakybucket.ErrorFull.
+	OnError(bucket string, err error)
+}
+
+// observability is the Observer and tracer a Storage was configured
+// with, carried onto every Bucket it creates. It's embedded by value so
+// zero-valued Storage/Bucket structs (no observer, no tracer) stay
+// no-ops.
+type observability struct {
+	observer Observer
+	tracer   trace.Tracer
+}
+
+func (o observability) onAllow(bucket string, amount, remaining uint, latency time.Duration) {
+	if o.observer != nil {
+		o.observer.OnAllow(bucket, amount, remaining, latency)
+	}
+}
+
+func (o observability) onDeny(bucket string, amount uint, resetIn time.Duration) {
+	if o.observer != nil {
+		o.observer.OnDeny(bucket, amount, resetIn)
+	}
+}
+
+func (o observability) onError(bucket string, err error) {
+	if o.observer != nil {
+		o.observer.OnError(bucket, err)
+	}
+}
+
+// startSpan starts a span covering one or more Redis commands against a
+// bucket, if a tracer is configured. The returned span is nil when it
+// isn't, so callers can unconditionally `if span != nil { defer span.End() }`.
+// redis.command is set by endSpan rather than here, since a single
+// Create span can cover more than one command (e.g. GET then PTTL) and
+// only the last one actually run is worth reporting.
+func (o observability) startSpan(ctx context.Context, name string, capacity, remaining uint) (context.Context, trace.Span) {
+	if o.tracer == nil {
+		return ctx, nil
+	}
+	return o.tracer.Start(ctx, name, trace.WithAttributes(
+		attribute.Int64("bucket.capacity", int64(capacity)),
+		attribute.Int64("bucket.remaining", int64(remaining)),
+	))
+}
+
+// endSpan records the outcome of a span, tagging bucket.name, the last
+// Redis command actually run, and the bucket.remaining the call ended
+// with.
+func endSpan(span trace.Span, name, command string, remaining uint, err error) {
+	if span == nil {
+		return
+	}
+	span.SetAttributes(
+		attribute.String("bucket.name", name),
+		attribute.String("redis.command", command),
+		attribute.Int64("bucket.remaining", int64(remaining)),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
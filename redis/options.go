@@ -0,0 +1,202 @@
+package redis
+
+import (
+	"crypto/tls"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+// defaultMaxIdle matches the pool size NewBucket has always used.
+const defaultMaxIdle = 5
+
+// Options configures how a Storage connects to Redis. Exactly one of a
+// direct Network/Address, Sentinel, or Cluster topology should be set;
+// Cluster takes precedence over Sentinel, which takes precedence over a
+// direct connection.
+type Options struct {
+	// Network and Address dial a single Redis node directly. Network
+	// defaults to "tcp". Ignored when Sentinel or Cluster is set.
+	Network string
+	Address string
+
+	Password string
+	Database int
+
+	// Sentinel, when set, discovers the current master address through a
+	// set of Sentinel nodes instead of dialing Address directly.
+	Sentinel *SentinelOptions
+
+	// Cluster, when set, shards buckets across multiple nodes by hashing
+	// the bucket name.
+	Cluster *ClusterOptions
+
+	// Pool tuning, mirroring the fields on redis.Pool.
+	MaxIdle     int
+	MaxActive   int
+	IdleTimeout time.Duration
+	Wait        bool
+
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	TLSConfig    *tls.Config
+
+	// TestOnBorrowAfter, when non-zero, PINGs a pooled connection before
+	// handing it out once it has sat idle longer than this.
+	TestOnBorrowAfter time.Duration
+}
+
+// SentinelOptions describes a Sentinel-monitored master.
+type SentinelOptions struct {
+	// Addrs is the list of Sentinel node addresses ("host:port") to query.
+	// They are tried in order until one answers.
+	Addrs []string
+	// MasterName is the name Sentinel was configured to monitor the
+	// master under.
+	MasterName string
+}
+
+// ClusterOptions describes a statically sharded set of Redis nodes.
+type ClusterOptions struct {
+	// Slots maps CRC16 hash-slot ranges (0-16383, inclusive) to the node
+	// that owns them. A bucket name is routed by CRC16(name) % 16384.
+	Slots []ClusterSlot
+}
+
+// ClusterSlot is a single entry in a cluster's slot map.
+type ClusterSlot struct {
+	Start, End int
+
+	// Network defaults to "tcp".
+	Network string
+	Address string
+}
+
+func (o *Options) setDefaults() {
+	if o.MaxIdle == 0 {
+		o.MaxIdle = defaultMaxIdle
+	}
+}
+
+// dialOptions translates Options into the redis.DialOption values redigo
+// expects.
+func (o *Options) dialOptions() []redis.DialOption {
+	var opts []redis.DialOption
+	if o.DialTimeout > 0 {
+		opts = append(opts, redis.DialConnectTimeout(o.DialTimeout))
+	}
+	if o.ReadTimeout > 0 {
+		opts = append(opts, redis.DialReadTimeout(o.ReadTimeout))
+	}
+	if o.WriteTimeout > 0 {
+		opts = append(opts, redis.DialWriteTimeout(o.WriteTimeout))
+	}
+	if o.TLSConfig != nil {
+		opts = append(opts, redis.DialTLSConfig(o.TLSConfig), redis.DialUseTLS(true))
+	}
+	if o.Database != 0 {
+		opts = append(opts, redis.DialDatabase(o.Database))
+	}
+	if o.Password != "" {
+		opts = append(opts, redis.DialPassword(o.Password))
+	}
+	return opts
+}
+
+// newPool builds a redis.Pool around dial using the tuning in Options.
+func (o *Options) newPool(dial func() (redis.Conn, error)) *redis.Pool {
+	pool := &redis.Pool{
+		Dial:        dial,
+		MaxIdle:     o.MaxIdle,
+		MaxActive:   o.MaxActive,
+		IdleTimeout: o.IdleTimeout,
+		Wait:        o.Wait,
+	}
+	if o.TestOnBorrowAfter > 0 {
+		pool.TestOnBorrow = func(c redis.Conn, lastUsed time.Time) error {
+			if time.Since(lastUsed) < o.TestOnBorrowAfter {
+				return nil
+			}
+			_, err := c.Do("PING")
+			return err
+		}
+	}
+	return pool
+}
+
+// directDialer dials network/address with the tuning in Options applied.
+func directDialer(o *Options, network, address string) func() (redis.Conn, error) {
+	return func() (redis.Conn, error) {
+		return redis.Dial(network, address, o.dialOptions()...)
+	}
+}
+
+// resolveClient turns Options into either a single RedisClient (direct or
+// Sentinel) or a cluster router, per the precedence documented on
+// Options. Exactly one of the two return values is non-nil on success.
+// Shared by every constructor that accepts Options, so fixed-window and
+// sliding-window storages configure the same way.
+func resolveClient(opts *Options) (RedisClient, *clusterRouter, error) {
+	opts.setDefaults()
+
+	if opts.Cluster != nil {
+		cluster, err := newClusterRouter(opts)
+		return nil, cluster, err
+	}
+
+	var dial func() (redis.Conn, error)
+	if opts.Sentinel != nil {
+		sentinel := &sentinelClient{addrs: opts.Sentinel.Addrs, masterName: opts.Sentinel.MasterName}
+		dial = sentinel.dialer(opts)
+	} else {
+		network := opts.Network
+		if network == "" {
+			network = "tcp"
+		}
+		dial = directDialer(opts, network, opts.Address)
+	}
+
+	pool := opts.newPool(dial)
+	conn := pool.Get()
+	_, err := conn.Do("PING")
+	conn.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return newRedigoClient(pool), nil, nil
+}
+
+// newClusterRouter builds a clusterRouter that routes each bucket to the
+// node owning its hash slot, reusing one client per distinct node
+// address.
+func newClusterRouter(opts *Options) (*clusterRouter, error) {
+	clients := make(map[string]RedisClient, len(opts.Cluster.Slots))
+	router := &clusterRouter{}
+
+	for _, slot := range opts.Cluster.Slots {
+		network := slot.Network
+		if network == "" {
+			network = "tcp"
+		}
+
+		key := network + " " + slot.Address
+		client, ok := clients[key]
+		if !ok {
+			pool := opts.newPool(directDialer(opts, network, slot.Address))
+			conn := pool.Get()
+			_, err := conn.Do("PING")
+			conn.Close()
+			if err != nil {
+				return nil, err
+			}
+			client = newRedigoClient(pool)
+			clients[key] = client
+		}
+
+		router.nodes = append(router.nodes, clusterNode{start: slot.Start, end: slot.End, client: client})
+	}
+
+	return router, nil
+}
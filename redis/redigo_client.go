@@ -0,0 +1,95 @@
+package redis
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+// redigoClient adapts a *redis.Pool to RedisClient, the default backend
+// for Storage when no other driver is supplied.
+type redigoClient struct {
+	pool *redis.Pool
+
+	mu      sync.Mutex
+	scripts map[*Script]*redis.Script
+}
+
+func newRedigoClient(pool *redis.Pool) *redigoClient {
+	return &redigoClient{pool: pool, scripts: make(map[*Script]*redis.Script)}
+}
+
+// redigoScript returns the cached redigo Script for s, compiling and
+// caching it on first use. Its Hash() is reused below; its Do method
+// isn't, since it has no timeout-bounded variant.
+func (c *redigoClient) redigoScript(s *Script) *redis.Script {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if rs, ok := c.scripts[s]; ok {
+		return rs
+	}
+	rs := redis.NewScript(s.KeyCount, s.Source)
+	c.scripts[s] = rs
+	return rs
+}
+
+func (c *redigoClient) Do(ctx context.Context, cmd string, args ...interface{}) (interface{}, error) {
+	conn, err := c.pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return doWithContext(ctx, conn, cmd, args...)
+}
+
+func (c *redigoClient) EvalSha(ctx context.Context, script *Script, keys []string, args ...interface{}) (interface{}, error) {
+	conn, err := c.pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	keysAndArgs := make([]interface{}, 0, len(keys)+len(args))
+	for _, k := range keys {
+		keysAndArgs = append(keysAndArgs, k)
+	}
+	keysAndArgs = append(keysAndArgs, args...)
+
+	hash := c.redigoScript(script).Hash()
+	evalShaArgs := append([]interface{}{hash, script.KeyCount}, keysAndArgs...)
+
+	reply, err := doWithContext(ctx, conn, "EVALSHA", evalShaArgs...)
+	if e, ok := err.(redis.Error); ok && strings.HasPrefix(string(e), "NOSCRIPT ") {
+		evalArgs := append([]interface{}{script.Source, script.KeyCount}, keysAndArgs...)
+		reply, err = doWithContext(ctx, conn, "EVAL", evalArgs...)
+	}
+	return reply, err
+}
+
+func (c *redigoClient) Close() error {
+	return c.pool.Close()
+}
+
+// doWithContext runs cmd on conn, bounded by ctx. When ctx carries a
+// deadline, the command runs via redis.DoWithTimeout so a slow Redis
+// reply is abandoned without racing a second goroutine against the
+// connection redigo documents as unsafe for concurrent use (unlike a
+// goroutine-plus-Close race, DoWithTimeout sets a read deadline on the
+// same, single synchronous call). Without a deadline, ctx is only
+// checked before issuing the command; redigo has no way to interrupt a
+// command already in flight on a conn with no timeout.
+func doWithContext(ctx context.Context, conn redis.Conn, cmd string, args ...interface{}) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		return redis.DoWithTimeout(conn, time.Until(deadline), cmd, args...)
+	}
+	return conn.Do(cmd, args...)
+}
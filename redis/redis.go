@@ -1,19 +1,28 @@
 package redis
 
 import (
+	"context"
+	"sync"
 	"time"
 
 	leakybucket "github.com/DropLive/leakybucket"
 	"github.com/garyburd/redigo/redis"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// Bucket object
+// Bucket object. The Redis-side counter is updated atomically, but a
+// *Bucket is also shared safely across goroutines: mu guards the local
+// remaining/reset cache that AddContext refreshes on every call.
 type Bucket struct {
-	name                string
-	capacity, remaining uint
-	reset               time.Time
-	rate                time.Duration
-	pool                *redis.Pool
+	name     string
+	capacity uint
+	rate     time.Duration
+	client   RedisClient
+	obs      observability
+
+	mu        sync.Mutex
+	remaining uint
+	reset     time.Time
 }
 
 // Capacity of Bucket
@@ -23,110 +32,205 @@ func (b *Bucket) Capacity() uint {
 
 // Remaining space in the Bucket.
 func (b *Bucket) Remaining() uint {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	return b.remaining
 }
 
 // Reset returns when the Bucket will be drained.
 func (b *Bucket) Reset() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	return b.reset
 }
 
 // State of bucket
 func (b *Bucket) State() leakybucket.BucketState {
-	return leakybucket.BucketState{Capacity: b.Capacity(), Remaining: b.Remaining(), Reset: b.Reset()}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return leakybucket.BucketState{Capacity: b.capacity, Remaining: b.remaining, Reset: b.reset}
 }
 
 var millisecond = int64(time.Millisecond)
 
-func (b *Bucket) updateOldReset() error {
-	if b.reset.Unix() > time.Now().Unix() {
-		return nil
-	}
-
-	conn := b.pool.Get()
-	defer conn.Close()
-
-	ttl, err := conn.Do("PTTL", b.name)
-	if err != nil {
-		return err
-	}
-	b.reset = time.Now().Add(time.Duration(ttl.(int64) * millisecond))
-	return nil
+// addLuaScript atomically checks the bucket's current counter against its
+// capacity and, if there is room, applies INCRBY and (on first write)
+// PEXPIRE in a single round-trip. This closes the race where two
+// concurrent clients both pass a separate GET/capacity check before
+// either one's INCRBY lands.
+//
+// KEYS[1] = bucket name
+// ARGV[1] = amount to add
+// ARGV[2] = capacity
+// ARGV[3] = expiry in milliseconds
+//
+// Returns {allowed, count, pttl}. allowed is 0 when the request was
+// rejected for exceeding capacity, in which case count is the unchanged
+// current counter.
+var addLuaScript = &Script{
+	KeyCount: 1,
+	Source: `
+local current = tonumber(redis.call('GET', KEYS[1])) or 0
+local amount = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+if current + amount > capacity then
+	return {0, current, redis.call('PTTL', KEYS[1])}
+end
+local new = redis.call('INCRBY', KEYS[1], amount)
+if new == amount then
+	redis.call('PEXPIRE', KEYS[1], ARGV[3])
+end
+return {1, new, redis.call('PTTL', KEYS[1])}
+`,
 }
 
 // Add to the Bucket.
 func (b *Bucket) Add(amount uint) (leakybucket.BucketState, error) {
-	conn := b.pool.Get()
-	defer conn.Close()
+	return b.AddContext(context.Background(), amount)
+}
 
-	if count, err := redis.Uint64(conn.Do("GET", b.name)); err != nil {
-		// handle the key not being set
-		if err == redis.ErrNil {
-			b.remaining = b.capacity
-		} else {
-			return b.State(), err
-		}
-	} else {
-		b.remaining = b.capacity - min(uint(count), b.capacity)
-	}
+// AddContext is Add, bounded by ctx. With the default redigo client it
+// returns ctx.Err() if ctx is already done before the script is sent, and
+// a script still running at ctx's deadline ends with a timeout error; a
+// cancellation of a ctx without a deadline does not interrupt a script
+// already in flight (see doWithContext). Other RedisClients handle ctx as
+// their driver does.
+func (b *Bucket) AddContext(ctx context.Context, amount uint) (leakybucket.BucketState, error) {
+	ctx, span := b.obs.startSpan(ctx, "leakybucket.Add", b.capacity, b.Remaining())
 
-	if amount > b.remaining {
-		b.updateOldReset()
-		return b.State(), leakybucket.ErrorFull
-	}
+	start := time.Now()
 
 	// Go y u no have Milliseconds method? Why only Seconds and Nanoseconds?
 	expiry := int(b.rate.Nanoseconds() / millisecond)
 
-	count, err := redis.Uint64(conn.Do("INCRBY", b.name, amount))
+	reply, err := redis.Values(b.client.EvalSha(ctx, addLuaScript, []string{b.name}, amount, b.capacity, expiry))
 	if err != nil {
+		b.obs.onError(b.name, err)
+		endSpan(span, b.name, "EVALSHA", b.Remaining(), err)
 		return b.State(), err
-	} else if uint(count) == amount {
-		if _, err := conn.Do("PEXPIRE", b.name, expiry); err != nil {
-			return b.State(), err
-		}
 	}
 
-	b.updateOldReset()
+	var allowed, count, pttl int64
+	if _, err := redis.Scan(reply, &allowed, &count, &pttl); err != nil {
+		b.obs.onError(b.name, err)
+		endSpan(span, b.name, "EVALSHA", b.Remaining(), err)
+		return b.State(), err
+	}
 
+	b.mu.Lock()
 	// Ensure we can't overflow
 	b.remaining = b.capacity - min(uint(count), b.capacity)
+
+	if pttl >= 0 {
+		b.reset = time.Now().Add(time.Duration(pttl * millisecond))
+	} else {
+		// key was never written (e.g. a single oversized request against
+		// a fresh bucket), so fall back to a full rate window.
+		b.reset = time.Now().Add(b.rate)
+	}
+	remaining, reset := b.remaining, b.reset
+	b.mu.Unlock()
+
+	endSpan(span, b.name, "EVALSHA", remaining, nil)
+
+	if allowed == 0 {
+		b.obs.onDeny(b.name, amount, time.Until(reset))
+		return b.State(), leakybucket.ErrorFull
+	}
+
+	b.obs.onAllow(b.name, amount, remaining, time.Since(start))
 	return b.State(), nil
 }
 
 // Storage is a redis-based, non thread-safe leaky Bucket factory.
 type Storage struct {
-	pool *redis.Pool
+	// client serves every bucket when Storage is configured for a single
+	// node or a Sentinel-discovered master. Nil when cluster is set.
+	client RedisClient
+
+	// cluster routes a bucket name to the client of the node that owns
+	// its hash slot. Nil unless Storage was built in cluster mode.
+	cluster *clusterRouter
+
+	obs observability
+}
+
+// clientFor returns the RedisClient that should serve the given bucket
+// name.
+func (s *Storage) clientFor(name string) (RedisClient, error) {
+	if s.cluster != nil {
+		return s.cluster.clientFor(name)
+	}
+	return s.client, nil
+}
+
+// WithObserver attaches an Observer that every Bucket this Storage
+// creates afterwards will report Add outcomes to. It returns s so it can
+// be chained onto a constructor call.
+func (s *Storage) WithObserver(o Observer) *Storage {
+	s.obs.observer = o
+	return s
+}
+
+// WithTracer wraps every Redis command this Storage's buckets run in a
+// span from t. It returns s so it can be chained onto a constructor call.
+func (s *Storage) WithTracer(t trace.Tracer) *Storage {
+	s.obs.tracer = t
+	return s
 }
 
 // Create a Bucket.
-func (s *Storage) Create(name string, capacity uint, rate time.Duration) (leakybucket.Bucket, error) {
-	conn := s.pool.Get()
-	defer conn.Close()
+func (s *Storage) Create(name string, capacity uint, rate time.Duration) (ContextBucket, error) {
+	return s.CreateContext(context.Background(), name, capacity, rate)
+}
 
-	if count, err := redis.Uint64(conn.Do("GET", name)); err != nil {
+// CreateContext is Create, bounded by ctx. With the default redigo client
+// it returns ctx.Err() if ctx is already done before a command is sent,
+// and a command still running at ctx's deadline ends with a timeout
+// error; a cancellation of a ctx without a deadline does not interrupt a
+// command already in flight (see doWithContext). Other RedisClients
+// handle ctx as their driver does.
+func (s *Storage) CreateContext(ctx context.Context, name string, capacity uint, rate time.Duration) (ContextBucket, error) {
+	client, err := s.clientFor(name)
+	if err != nil {
+		s.obs.onError(name, err)
+		return nil, err
+	}
+
+	ctx, span := s.obs.startSpan(ctx, "leakybucket.Create", capacity, 0)
+
+	if count, err := redis.Uint64(client.Do(ctx, "GET", name)); err != nil {
 		if err != redis.ErrNil {
+			s.obs.onError(name, err)
+			endSpan(span, name, "GET", 0, err)
 			return nil, err
 		}
 		// return a standard Bucket if key was not found
+		endSpan(span, name, "GET", capacity, nil)
 		return &Bucket{
 			name:      name,
 			capacity:  capacity,
 			remaining: capacity,
 			reset:     time.Now().Add(rate),
 			rate:      rate,
-			pool:      s.pool,
+			client:    client,
+			obs:       s.obs,
 		}, nil
-	} else if ttl, err := redis.Int64(conn.Do("PTTL", name)); err != nil {
+	} else if ttl, err := redis.Int64(client.Do(ctx, "PTTL", name)); err != nil {
+		s.obs.onError(name, err)
+		endSpan(span, name, "PTTL", 0, err)
 		return nil, err
 	} else {
+		remaining := capacity - min(capacity, uint(count))
+		endSpan(span, name, "PTTL", remaining, nil)
 		b := &Bucket{
 			name:      name,
 			capacity:  capacity,
-			remaining: capacity - min(capacity, uint(count)),
+			remaining: remaining,
 			reset:     time.Now().Add(time.Duration(ttl * millisecond)),
 			rate:      rate,
-			pool:      s.pool,
+			client:    client,
+			obs:       s.obs,
 		}
 		return b, nil
 	}
@@ -134,30 +238,52 @@ func (s *Storage) Create(name string, capacity uint, rate time.Duration) (leakyb
 
 // NewBucket initializes the connection to redis.
 func NewBucket(network, address string, password string) (*Storage, error) {
-	s := &Storage{
-		pool: redis.NewPool(func() (redis.Conn, error) {
-			c, err := redis.Dial(network, address)
-			if nil != err {
+	pool := redis.NewPool(func() (redis.Conn, error) {
+		c, err := redis.Dial(network, address)
+		if nil != err {
+			return nil, err
+		}
+
+		if "" != password {
+			if _, err := c.Do("AUTH", password); err != nil {
+				c.Close()
 				return nil, err
 			}
+		}
 
-			if "" != password {
-				if _, err := c.Do("AUTH", password); err != nil {
-					c.Close()
-					return nil, err
-				}
-			}
+		return c, nil
+	}, 5)
+
+	return newStorageFromPool(pool)
+}
+
+// NewBucketWithOptions initializes a Storage from an Options struct. It
+// supports everything NewBucket does plus Sentinel-discovered masters,
+// cluster sharding, and the pool/dial tuning redigo users expect.
+func NewBucketWithOptions(opts Options) (*Storage, error) {
+	client, cluster, err := resolveClient(&opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Storage{client: client, cluster: cluster}, nil
+}
+
+// NewStorageFromClient lets a caller bring their own already-configured
+// RedisClient — a go-redis (cluster) client, a tracing-wrapped client, or
+// any other driver — instead of going through NewBucket/NewBucketWithOptions.
+func NewStorageFromClient(client RedisClient) *Storage {
+	return &Storage{client: client}
+}
 
-			return c, nil
-		}, 5)}
-	// When using a connection pool, you only get connection errors while trying to send commands.
-	// Try to PING so we can fail-fast in the case of invalid address.
-	conn := s.pool.Get()
+// newStorageFromPool wraps a ready redigo pool in a Storage, failing fast
+// if the node can't be reached.
+func newStorageFromPool(pool *redis.Pool) (*Storage, error) {
+	conn := pool.Get()
 	defer conn.Close()
 	if _, err := conn.Do("PING"); err != nil {
 		return nil, err
 	}
-	return s, nil
+	return &Storage{client: newRedigoClient(pool)}, nil
 }
 
 func min(a, b uint) uint {
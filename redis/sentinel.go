@@ -0,0 +1,92 @@
+package redis
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+// sentinelDialTimeout bounds how long we'll wait for a Sentinel node to
+// answer before moving on to the next one.
+const sentinelDialTimeout = 2 * time.Second
+
+// sentinelClient resolves the current master address for a Sentinel-
+// monitored set, caching the last address it resolved so that only a
+// failed dial against it triggers a fresh `SENTINEL get-master-addr-by-name`
+// round-trip.
+type sentinelClient struct {
+	addrs      []string
+	masterName string
+
+	mu     sync.Mutex
+	cached string
+}
+
+// cachedAddr returns the last master address dialer resolved, or "" if
+// none has been resolved yet.
+func (s *sentinelClient) cachedAddr() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cached
+}
+
+// setCachedAddr records addr as the last-known master, so subsequent
+// dials skip Sentinel until addr itself fails.
+func (s *sentinelClient) setCachedAddr(addr string) {
+	s.mu.Lock()
+	s.cached = addr
+	s.mu.Unlock()
+}
+
+// masterAddr asks each Sentinel node in turn for the current master of
+// masterName, returning the first usable answer.
+func (s *sentinelClient) masterAddr() (string, error) {
+	var lastErr error
+	for _, addr := range s.addrs {
+		conn, err := redis.DialTimeout("tcp", addr, sentinelDialTimeout, sentinelDialTimeout, sentinelDialTimeout)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		reply, err := redis.Strings(conn.Do("SENTINEL", "get-master-addr-by-name", s.masterName))
+		conn.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if len(reply) != 2 {
+			lastErr = fmt.Errorf("redis: sentinel %s returned malformed master address %v", addr, reply)
+			continue
+		}
+
+		return net.JoinHostPort(reply[0], reply[1]), nil
+	}
+
+	return "", fmt.Errorf("redis: no reachable sentinel for master %q: %w", s.masterName, lastErr)
+}
+
+// dialer returns a redis.Pool-compatible Dial func that dials the
+// last-known Sentinel master, only falling back to a fresh
+// `SENTINEL get-master-addr-by-name` lookup when that address is unset or
+// fails to dial, so a pool refreshes to the new master after a failover
+// without hitting Sentinel on every connection.
+func (s *sentinelClient) dialer(o *Options) func() (redis.Conn, error) {
+	return func() (redis.Conn, error) {
+		if addr := s.cachedAddr(); addr != "" {
+			if conn, err := redis.Dial("tcp", addr, o.dialOptions()...); err == nil {
+				return conn, nil
+			}
+		}
+
+		addr, err := s.masterAddr()
+		if err != nil {
+			return nil, err
+		}
+		s.setCachedAddr(addr)
+		return redis.Dial("tcp", addr, o.dialOptions()...)
+	}
+}
@@ -0,0 +1,138 @@
+package redis
+
+import (
+	"net"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+// startFakeSentinel starts a TCP listener that answers every
+// "SENTINEL get-master-addr-by-name" request with addrs[min(call, len(addrs)-1)],
+// so a test can make the "master" move after the first lookup. It
+// returns the listener's address and a counter of requests served.
+func startFakeSentinel(t *testing.T, addrs ...string) (string, *int32) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	var calls int32
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(c net.Conn) {
+				defer c.Close()
+				buf := make([]byte, 1024)
+				if _, err := c.Read(buf); err != nil {
+					return
+				}
+
+				n := atomic.AddInt32(&calls, 1) - 1
+				if int(n) >= len(addrs) {
+					n = int32(len(addrs) - 1)
+				}
+				host, port, err := net.SplitHostPort(addrs[n])
+				if err != nil {
+					return
+				}
+				c.Write([]byte("*2\r\n$" + strconv.Itoa(len(host)) + "\r\n" + host +
+					"\r\n$" + strconv.Itoa(len(port)) + "\r\n" + port + "\r\n"))
+			}(conn)
+		}
+	}()
+
+	return ln.Addr().String(), &calls
+}
+
+// startFakeMaster starts a TCP listener that accepts connections and
+// closes each one at once, standing in for a Redis master that redis.Dial only
+// needs to TCP-connect to. The returned func stops it, simulating the
+// master going away.
+func startFakeMaster(t *testing.T) (addr string, stop func()) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	stopped := false
+	stop = func() {
+		if stopped {
+			return
+		}
+		stopped = true
+		ln.Close()
+		close(done)
+	}
+	t.Cleanup(stop)
+
+	return ln.Addr().String(), stop
+}
+
+// TestSentinelDialerCachesMasterAddr checks that dialer reuses the last
+// resolved master address across calls (no repeat Sentinel round-trip)
+// and only re-resolves once that address stops accepting connections.
+func TestSentinelDialerCachesMasterAddr(t *testing.T) {
+	primary, stopPrimary := startFakeMaster(t)
+	secondary, _ := startFakeMaster(t)
+	sentinelAddr, calls := startFakeSentinel(t, primary, secondary)
+
+	sc := &sentinelClient{addrs: []string{sentinelAddr}, masterName: "mymaster"}
+	dial := sc.dialer(&Options{})
+
+	conn, err := dial()
+	if err != nil {
+		t.Fatalf("first dial: %v", err)
+	}
+	conn.Close()
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Fatalf("sentinel calls after first dial = %d, want 1", got)
+	}
+	if sc.cachedAddr() != primary {
+		t.Fatalf("cached addr = %q, want primary %q", sc.cachedAddr(), primary)
+	}
+
+	conn, err = dial()
+	if err != nil {
+		t.Fatalf("second dial (cached): %v", err)
+	}
+	conn.Close()
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Fatalf("sentinel calls after cached dial = %d, want still 1", got)
+	}
+
+	// Bring the cached master down; the next dial must fail over to the
+	// new address Sentinel now reports.
+	stopPrimary()
+
+	conn, err = dial()
+	if err != nil {
+		t.Fatalf("third dial (after failover): %v", err)
+	}
+	conn.Close()
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Fatalf("sentinel calls after failover dial = %d, want 2", got)
+	}
+	if sc.cachedAddr() != secondary {
+		t.Fatalf("cached addr after failover = %q, want secondary %q", sc.cachedAddr(), secondary)
+	}
+}
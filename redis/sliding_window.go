@@ -0,0 +1,295 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	leakybucket "github.com/DropLive/leakybucket"
+	"github.com/garyburd/redigo/redis"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// slidingWindowScript tracks one sorted-set member per unit of capacity
+// consumed, scored by the millisecond timestamp it was added. Unlike the
+// fixed-window addLuaScript, nothing is reset in one shot: members older
+// than the window are evicted on every call, so a client can't spend a
+// full capacity right before a window boundary and another full capacity
+// right after.
+//
+// KEYS[1] = bucket name
+// ARGV[1] = now, in epoch milliseconds
+// ARGV[2] = window, in milliseconds
+// ARGV[3] = capacity
+// ARGV[4] = amount to add
+// ARGV[5] = a per-call nonce used to build unique member ids
+//
+// Returns {allowed, count, reset}, where reset is an epoch millisecond:
+// on an accepted request, now + window (when the members just added
+// expire); on a rejected one, when the oldest surviving member expires,
+// or now + window if there is none. allowed is 0 when the request
+// was rejected for exceeding capacity, in which case count is the
+// unchanged surviving cardinality.
+var slidingWindowScript = &Script{
+	KeyCount: 1,
+	Source: `
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local capacity = tonumber(ARGV[3])
+local amount = tonumber(ARGV[4])
+local nonce = ARGV[5]
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+
+local card = redis.call('ZCARD', key)
+if card + amount > capacity then
+	local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+	local reset = now + window
+	if oldest[2] then
+		reset = tonumber(oldest[2]) + window
+	end
+	return {0, card, reset}
+end
+
+local members = {}
+for i = 1, amount do
+	table.insert(members, now)
+	table.insert(members, nonce .. '-' .. i)
+end
+redis.call('ZADD', key, unpack(members))
+redis.call('PEXPIRE', key, window)
+
+return {1, card + amount, now + window}
+`,
+}
+
+// memberSeq gives nextMemberNonce a process-wide unique suffix. Redis
+// scripts run with math.random reseeded to a fixed value for replication
+// determinism, so member ids can't rely on Lua-side randomness.
+var memberSeq uint64
+
+// nextMemberNonce returns a ULID-like (sortable, unique) id fragment used
+// to build slidingWindowScript's sorted-set members.
+func nextMemberNonce() string {
+	seq := atomic.AddUint64(&memberSeq, 1)
+	return strconv.FormatInt(time.Now().UnixNano(), 36) + "-" + strconv.FormatUint(seq, 36)
+}
+
+// slidingWindowBucket is the leakybucket.Bucket returned by
+// SlidingWindowStorage. Like redis.Bucket, the Redis-side state is
+// atomic but the local cache isn't: mu guards remaining/reset against
+// concurrent AddContext/Remaining/Reset/State calls.
+type slidingWindowBucket struct {
+	name     string
+	capacity uint
+	window   time.Duration
+	client   RedisClient
+	obs      observability
+
+	mu        sync.Mutex
+	remaining uint
+	reset     time.Time
+}
+
+// Capacity of the Bucket.
+func (b *slidingWindowBucket) Capacity() uint {
+	return b.capacity
+}
+
+// Remaining space in the Bucket.
+func (b *slidingWindowBucket) Remaining() uint {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.remaining
+}
+
+// Reset returns the reset time from the last Create or Add: when the
+// oldest entry in the window expires (after Create or a rejected Add), or
+// when the entries an accepted Add just made expire.
+func (b *slidingWindowBucket) Reset() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.reset
+}
+
+// State of the Bucket.
+func (b *slidingWindowBucket) State() leakybucket.BucketState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return leakybucket.BucketState{Capacity: b.capacity, Remaining: b.remaining, Reset: b.reset}
+}
+
+// Add to the Bucket.
+func (b *slidingWindowBucket) Add(amount uint) (leakybucket.BucketState, error) {
+	return b.AddContext(context.Background(), amount)
+}
+
+// AddContext is Add, bounded by ctx.
+func (b *slidingWindowBucket) AddContext(ctx context.Context, amount uint) (leakybucket.BucketState, error) {
+	ctx, span := b.obs.startSpan(ctx, "leakybucket.Add", b.capacity, b.Remaining())
+
+	start := time.Now()
+	now := start.UnixNano() / millisecond
+	window := int64(b.window / time.Millisecond)
+
+	reply, err := redis.Values(b.client.EvalSha(ctx, slidingWindowScript, []string{b.name}, now, window, b.capacity, amount, nextMemberNonce()))
+	if err != nil {
+		b.obs.onError(b.name, err)
+		endSpan(span, b.name, "EVALSHA", b.Remaining(), err)
+		return b.State(), err
+	}
+
+	var allowed, count, reset int64
+	if _, err := redis.Scan(reply, &allowed, &count, &reset); err != nil {
+		b.obs.onError(b.name, err)
+		endSpan(span, b.name, "EVALSHA", b.Remaining(), err)
+		return b.State(), err
+	}
+
+	b.mu.Lock()
+	b.remaining = b.capacity - min(uint(count), b.capacity)
+	b.reset = time.Unix(0, reset*millisecond)
+	remaining, newReset := b.remaining, b.reset
+	b.mu.Unlock()
+
+	endSpan(span, b.name, "EVALSHA", remaining, nil)
+
+	if allowed == 0 {
+		b.obs.onDeny(b.name, amount, time.Until(newReset))
+		return b.State(), leakybucket.ErrorFull
+	}
+
+	b.obs.onAllow(b.name, amount, remaining, time.Since(start))
+	return b.State(), nil
+}
+
+// SlidingWindowStorage is an alternative to Storage: instead of resetting
+// a fixed-window counter in one shot when its TTL expires, it keeps a
+// timestamped Redis sorted-set member per unit of capacity consumed and
+// continuously evicts
+// entries older than the window, trading a little extra memory per
+// bucket for smoother rate limiting across window boundaries.
+type SlidingWindowStorage struct {
+	client  RedisClient
+	cluster *clusterRouter
+
+	obs observability
+}
+
+func (s *SlidingWindowStorage) clientFor(name string) (RedisClient, error) {
+	if s.cluster != nil {
+		return s.cluster.clientFor(name)
+	}
+	return s.client, nil
+}
+
+// WithObserver attaches an Observer that every Bucket this Storage
+// creates afterwards will report Add outcomes to. It returns s so it can
+// be chained onto a constructor call.
+func (s *SlidingWindowStorage) WithObserver(o Observer) *SlidingWindowStorage {
+	s.obs.observer = o
+	return s
+}
+
+// WithTracer wraps every Redis command this Storage's buckets run in a
+// span from t. It returns s so it can be chained onto a constructor call.
+func (s *SlidingWindowStorage) WithTracer(t trace.Tracer) *SlidingWindowStorage {
+	s.obs.tracer = t
+	return s
+}
+
+// Create a sliding-window Bucket.
+func (s *SlidingWindowStorage) Create(name string, capacity uint, rate time.Duration) (ContextBucket, error) {
+	return s.CreateContext(context.Background(), name, capacity, rate)
+}
+
+// CreateContext is Create, bounded by ctx.
+func (s *SlidingWindowStorage) CreateContext(ctx context.Context, name string, capacity uint, rate time.Duration) (ContextBucket, error) {
+	client, err := s.clientFor(name)
+	if err != nil {
+		s.obs.onError(name, err)
+		return nil, err
+	}
+
+	ctx, span := s.obs.startSpan(ctx, "leakybucket.Create", capacity, 0)
+
+	now := time.Now()
+	nowMs := now.UnixNano() / millisecond
+	windowMs := int64(rate / time.Millisecond)
+
+	members, err := redis.Strings(client.Do(ctx, "ZRANGEBYSCORE", name, nowMs-windowMs, "+inf"))
+	if err != nil {
+		s.obs.onError(name, err)
+		endSpan(span, name, "ZRANGEBYSCORE", 0, err)
+		return nil, err
+	}
+
+	reset := now.Add(rate)
+	command := "ZRANGEBYSCORE"
+	if len(members) > 0 {
+		if score, err := redis.Int64(client.Do(ctx, "ZSCORE", name, members[0])); err == nil {
+			reset = time.Unix(0, (score+windowMs)*millisecond)
+			command = "ZSCORE"
+		}
+	}
+
+	count := uint(len(members))
+	remaining := capacity - min(capacity, count)
+	endSpan(span, name, command, remaining, nil)
+
+	return &slidingWindowBucket{
+		name:      name,
+		capacity:  capacity,
+		remaining: remaining,
+		reset:     reset,
+		window:    rate,
+		client:    client,
+		obs:       s.obs,
+	}, nil
+}
+
+// NewSlidingWindowStorage initializes a sliding-window Storage against a
+// single Redis node. It mirrors NewBucket.
+func NewSlidingWindowStorage(network, address, password string) (*SlidingWindowStorage, error) {
+	pool := redis.NewPool(func() (redis.Conn, error) {
+		c, err := redis.Dial(network, address)
+		if nil != err {
+			return nil, err
+		}
+
+		if "" != password {
+			if _, err := c.Do("AUTH", password); err != nil {
+				c.Close()
+				return nil, err
+			}
+		}
+
+		return c, nil
+	}, 5)
+
+	conn := pool.Get()
+	defer conn.Close()
+	if _, err := conn.Do("PING"); err != nil {
+		return nil, err
+	}
+
+	return &SlidingWindowStorage{client: newRedigoClient(pool)}, nil
+}
+
+// NewSlidingWindowStorageWithOptions initializes a sliding-window Storage
+// from an Options struct. It mirrors NewBucketWithOptions.
+func NewSlidingWindowStorageWithOptions(opts Options) (*SlidingWindowStorage, error) {
+	client, cluster, err := resolveClient(&opts)
+	if err != nil {
+		return nil, err
+	}
+	return &SlidingWindowStorage{client: client, cluster: cluster}, nil
+}
+
+// NewSlidingWindowStorageFromClient mirrors NewStorageFromClient.
+func NewSlidingWindowStorageFromClient(client RedisClient) *SlidingWindowStorage {
+	return &SlidingWindowStorage{client: client}
+}
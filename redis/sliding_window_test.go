@@ -0,0 +1,84 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeSlidingWindowEvalClient stands in for a real Redis server,
+// replicating slidingWindowScript's atomic check-then-add in Go behind a
+// mutex, so the concurrency test below can exercise slidingWindowBucket
+// without a live Redis.
+type fakeSlidingWindowEvalClient struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func newFakeSlidingWindowEvalClient() *fakeSlidingWindowEvalClient {
+	return &fakeSlidingWindowEvalClient{counts: make(map[string]int64)}
+}
+
+func (c *fakeSlidingWindowEvalClient) Do(ctx context.Context, cmd string, args ...interface{}) (interface{}, error) {
+	return nil, nil
+}
+
+func (c *fakeSlidingWindowEvalClient) EvalSha(ctx context.Context, script *Script, keys []string, args ...interface{}) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	name := keys[0]
+	capacity := int64(args[2].(uint))
+	amount := int64(args[3].(uint))
+
+	current := c.counts[name]
+	if current+amount > capacity {
+		return []interface{}{int64(0), current, int64(1000)}, nil
+	}
+	c.counts[name] = current + amount
+	return []interface{}{int64(1), current + amount, int64(1000)}, nil
+}
+
+func (c *fakeSlidingWindowEvalClient) Close() error { return nil }
+
+// TestSlidingWindowBucketAddConcurrentServedNeverExceedsCapacity mirrors
+// TestBucketAddConcurrentServedNeverExceedsCapacity in redis_test.go:
+// it hammers a single shared slidingWindowBucket from many goroutines,
+// checking both the served<=capacity invariant and (under -race) that
+// remaining/reset are synchronized.
+func TestSlidingWindowBucketAddConcurrentServedNeverExceedsCapacity(t *testing.T) {
+	const capacity = 100
+	const goroutines = 300
+
+	client := newFakeSlidingWindowEvalClient()
+	b := &slidingWindowBucket{
+		name:      "shared",
+		capacity:  capacity,
+		remaining: capacity,
+		reset:     time.Now().Add(time.Minute),
+		window:    time.Minute,
+		client:    client,
+	}
+
+	var served int64
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			if _, err := b.AddContext(context.Background(), 1); err == nil {
+				atomic.AddInt64(&served, 1)
+			}
+			_ = b.Remaining()
+			_ = b.Reset()
+			_ = b.State()
+		}()
+	}
+	wg.Wait()
+
+	if served > capacity {
+		t.Fatalf("served %d requests, want at most capacity %d", served, capacity)
+	}
+}